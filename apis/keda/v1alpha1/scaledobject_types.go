@@ -20,8 +20,12 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -61,6 +65,9 @@ const FallbackBehaviorStatic = "static"
 const FallbackBehaviorCurrentReplicas = "currentReplicas"
 const FallbackBehaviorCurrentReplicasIfHigher = "currentReplicasIfHigher"
 const FallbackBehaviorCurrentReplicasIfLower = "currentReplicasIfLower"
+const cpuString = "cpu"
+const memoryString = "memory"
+const containerResourceString = "container-resource"
 
 // HealthStatus is the status for a ScaledObject's health
 type HealthStatus struct {
@@ -108,6 +115,12 @@ type ScaledObjectSpec struct {
 	Triggers []ScaleTriggers `json:"triggers"`
 	// +optional
 	Fallback *Fallback `json:"fallback,omitempty"`
+	// PrimaryTriggerQueries lets an external controller (e.g. a progressive-delivery
+	// controller routing between a primary and a canary Deployment) override a named
+	// trigger's "query" metadata without duplicating the whole ScaledObject. Keyed by
+	// trigger name.
+	// +optional
+	PrimaryTriggerQueries map[string]string `json:"primaryTriggerQueries,omitempty"`
 }
 
 // Fallback is the spec for fallback options
@@ -128,6 +141,25 @@ type AdvancedConfig struct {
 	RestoreToOriginalReplicaCount bool `json:"restoreToOriginalReplicaCount,omitempty"`
 	// +optional
 	ScalingModifiers ScalingModifiers `json:"scalingModifiers,omitempty"`
+	// DownscaleForbiddenWindows lists recurring intervals during which replicas must never be
+	// scaled below the current count, regardless of the metric value. While a window is active the
+	// controller treats the effective MinReplicaCount as max(currentReplicas, MinReplicaCount) and
+	// IdleReplicaCount is ignored, so the workload can't be scaled to zero or below its configured
+	// floor during business-critical periods.
+	// +optional
+	DownscaleForbiddenWindows []TimeWindow `json:"downscaleForbiddenWindows,omitempty"`
+}
+
+// TimeWindow describes a recurring interval, expressed as a cron schedule plus a Duration-formatted
+// length, in a given timezone.
+type TimeWindow struct {
+	// Start is a cron expression (e.g. "0 9 * * 1-5") marking the beginning of the window.
+	Start string `json:"start"`
+	// Duration is how long the window lasts after Start, e.g. "8h".
+	Duration string `json:"duration"`
+	// +optional
+	// +kubebuilder:default=UTC
+	Timezone string `json:"timezone,omitempty"`
 }
 
 // ScalingModifiers describes advanced scaling logic options like formula
@@ -139,6 +171,8 @@ type ScalingModifiers struct {
 	// +optional
 	// +kubebuilder:validation:Enum=AverageValue;Value
 	MetricType autoscalingv2.MetricTargetType `json:"metricType,omitempty"`
+	// +optional
+	Watermark *Watermark `json:"watermark,omitempty"`
 }
 
 // HorizontalPodAutoscalerConfig specifies horizontal scale config
@@ -147,6 +181,60 @@ type HorizontalPodAutoscalerConfig struct {
 	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
 	// +optional
 	Name string `json:"name,omitempty"`
+	// AdoptExistingHPA makes the controller take ownership of a pre-existing HorizontalPodAutoscaler
+	// matching Name (or the default generated name) instead of failing because it isn't owned by this
+	// ScaledObject. This supersedes the older ScaledObjectTransferHpaOwnershipAnnotation, which is still
+	// honoured for backwards compatibility.
+	// +optional
+	AdoptExistingHPA bool `json:"adoptExistingHPA,omitempty"`
+}
+
+// ScaleTriggers reference the scaler that will be used
+type ScaleTriggers struct {
+	// +optional
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+	// +optional
+	UseCachedMetrics bool              `json:"useCachedMetrics,omitempty"`
+	Metadata         map[string]string `json:"metadata"`
+	// +optional
+	AuthenticationRef *ScaledObjectAuthRef `json:"authenticationRef,omitempty"`
+	// +optional
+	MetricType autoscalingv2.MetricTargetType `json:"metricType,omitempty"`
+	// ContainerName targets a single container's resource usage instead of the
+	// Pod-wide average. Only valid on container-resource triggers.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+	// +optional
+	Watermark *Watermark `json:"watermark,omitempty"`
+	// StabilizationWindowSeconds overrides, for this trigger only, the scale-down stabilization
+	// window that the controller synthesizes onto Advanced.HorizontalPodAutoscalerConfig.Behavior.
+	// Bounds mirror the upstream autoscaling/v2 HPABehavior field it layers onto.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	StabilizationWindowSeconds *int32 `json:"stabilizationWindowSeconds,omitempty"`
+}
+
+// Watermark defines a band around a metric's target value in which no scaling
+// action is taken. Once the observed metric drifts outside [LowWatermark,
+// HighWatermark], the closer watermark is used as the target in the standard
+// HPA ratio formula (desired = ceil(current * metric / watermark)), instead of
+// a single target value. This reduces replica churn for noisy metrics.
+type Watermark struct {
+	LowWatermark  string `json:"lowWatermark"`
+	HighWatermark string `json:"highWatermark"`
+	// Tolerance overrides the default HPA tolerance for this trigger when
+	// deciding whether the metric has drifted outside the watermarks.
+	// +optional
+	Tolerance string `json:"tolerance,omitempty"`
+}
+
+// ScaledObjectAuthRef reference the authentication object used by the trigger
+type ScaledObjectAuthRef struct {
+	Name string `json:"name"`
+	// +optional
+	Kind string `json:"kind,omitempty"`
 }
 
 // ScaleTarget holds the reference to the scale target Object
@@ -191,6 +279,12 @@ type ScaledObjectStatus struct {
 	TriggersTypes *string `json:"triggersTypes,omitempty"`
 	// +optional
 	AuthenticationsTypes *string `json:"authenticationsTypes,omitempty"`
+	// +optional
+	EffectiveTriggerQueries map[string]string `json:"effectiveTriggerQueries,omitempty"`
+	// ConversionWarnings lists metrics from an adopted HorizontalPodAutoscaler that could not be
+	// mapped onto a KEDA trigger, e.g. an Object metric source without a matching scaler.
+	// +optional
+	ConversionWarnings []string `json:"conversionWarnings,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -242,11 +336,66 @@ func (so *ScaledObject) NeedToBePausedByAnnotation() bool {
 	return shouldPause
 }
 
+// HasAdoptExistingHPA returns whether this ScaledObject should adopt a pre-existing HPA, either via
+// Advanced.HorizontalPodAutoscalerConfig.AdoptExistingHPA or the older transfer-hpa-ownership annotation.
+func (so *ScaledObject) HasAdoptExistingHPA() bool {
+	if so.Spec.Advanced != nil && so.Spec.Advanced.HorizontalPodAutoscalerConfig != nil && so.Spec.Advanced.HorizontalPodAutoscalerConfig.AdoptExistingHPA {
+		return true
+	}
+	_, transferAnnotationFound := so.GetAnnotations()[ScaledObjectTransferHpaOwnershipAnnotation]
+	return transferAnnotationFound
+}
+
 // IsUsingModifiers determines whether scalingModifiers are defined or not
 func (so *ScaledObject) IsUsingModifiers() bool {
 	return so.Spec.Advanced != nil && !reflect.DeepEqual(so.Spec.Advanced.ScalingModifiers, ScalingModifiers{})
 }
 
+// GetEffectiveTriggerMetadata returns trigger's metadata with its "query" field overridden by
+// PrimaryTriggerQueries, keyed by trigger name, if one is defined. This allows an external controller
+// to scope the same ScaledObject's query to a primary workload while the canary/original trigger
+// metadata is left untouched. The override is only applied when the trigger's own metadata already
+// has a "query" key; triggers that don't use one (e.g. a cpu trigger) are returned unchanged, since
+// fabricating a "query" key they don't understand would silently do nothing at best.
+func (so *ScaledObject) GetEffectiveTriggerMetadata(trigger ScaleTriggers) map[string]string {
+	if len(so.Spec.PrimaryTriggerQueries) == 0 || trigger.Name == "" {
+		return trigger.Metadata
+	}
+	if _, usesQuery := trigger.Metadata["query"]; !usesQuery {
+		return trigger.Metadata
+	}
+	query, ok := so.Spec.PrimaryTriggerQueries[trigger.Name]
+	if !ok {
+		return trigger.Metadata
+	}
+	metadata := make(map[string]string, len(trigger.Metadata))
+	for k, v := range trigger.Metadata {
+		metadata[k] = v
+	}
+	metadata["query"] = query
+	return metadata
+}
+
+// RecalculateEffectiveTriggerQueries recomputes ScaledObjectStatus.EffectiveTriggerQueries from the
+// current PrimaryTriggerQueries overrides, keyed by trigger name. The controller calls this alongside
+// trigger resolution so the effective query, after PrimaryTriggerQueries overrides are applied, is
+// visible on the ScaledObject's status rather than only inferable from the spec.
+func (so *ScaledObject) RecalculateEffectiveTriggerQueries() {
+	if len(so.Spec.PrimaryTriggerQueries) == 0 {
+		so.Status.EffectiveTriggerQueries = nil
+		return
+	}
+
+	effective := make(map[string]string)
+	for _, trigger := range so.Spec.Triggers {
+		metadata := so.GetEffectiveTriggerMetadata(trigger)
+		if query, ok := metadata["query"]; ok {
+			effective[trigger.Name] = query
+		}
+	}
+	so.Status.EffectiveTriggerQueries = effective
+}
+
 // GetHPAMinReplicas returns MinReplicas based on definition in ScaledObject or default value if not defined
 func (so *ScaledObject) GetHPAMinReplicas() *int32 {
 	if so.Spec.MinReplicaCount != nil && *so.Spec.MinReplicaCount > 0 {
@@ -284,8 +433,376 @@ func CheckReplicaCountBoundsAreValid(scaledObject *ScaledObject) error {
 	return nil
 }
 
+// parseWatermark parses a Watermark's string fields into floats, validating that lowWatermark does
+// not exceed highWatermark and, when set, that tolerance is non-negative.
+func parseWatermark(w *Watermark) (low, high, tolerance float64, err error) {
+	low, err = strconv.ParseFloat(w.LowWatermark, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("lowWatermark %q is not a valid number", w.LowWatermark)
+	}
+	high, err = strconv.ParseFloat(w.HighWatermark, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("highWatermark %q is not a valid number", w.HighWatermark)
+	}
+	if low > high {
+		return 0, 0, 0, fmt.Errorf("lowWatermark=%s must be less than or equal to highWatermark=%s", w.LowWatermark, w.HighWatermark)
+	}
+	if w.Tolerance != "" {
+		tolerance, err = strconv.ParseFloat(w.Tolerance, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("tolerance %q is not a valid number", w.Tolerance)
+		}
+		if tolerance < 0 {
+			return 0, 0, 0, fmt.Errorf("tolerance=%s must not be negative", w.Tolerance)
+		}
+	}
+	return low, high, tolerance, nil
+}
+
+// CheckWatermarksAreValid checks that every Watermark defined on a trigger, or on
+// scalingModifiers, has a LowWatermark that is not greater than its HighWatermark, and that any
+// Tolerance override is a non-negative number.
+func CheckWatermarksAreValid(scaledObject *ScaledObject) error {
+	checkOne := func(w *Watermark, context string) error {
+		if w == nil {
+			return nil
+		}
+		if _, _, _, err := parseWatermark(w); err != nil {
+			return fmt.Errorf("%s: %w", context, err)
+		}
+		return nil
+	}
+
+	for _, trigger := range scaledObject.Spec.Triggers {
+		if err := checkOne(trigger.Watermark, fmt.Sprintf("trigger %q", trigger.Name)); err != nil {
+			return err
+		}
+	}
+
+	if scaledObject.Spec.Advanced != nil {
+		if err := checkOne(scaledObject.Spec.Advanced.ScalingModifiers.Watermark, "scalingModifiers"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolveWatermarkTarget implements the dual-threshold Watermark scaling semantics. While
+// currentMetricValue stays within [LowWatermark, HighWatermark] (widened by Tolerance on both sides,
+// if set) withinBand is true and no scaling action should be taken. Once the metric drifts outside the
+// band, it returns the closer watermark as target, to be plugged into the standard HPA ratio formula
+// (desired = ceil(current * metric / target)) in place of a single fixed target.
+func ResolveWatermarkTarget(w *Watermark, currentMetricValue float64) (target float64, withinBand bool, err error) {
+	low, high, tolerance, err := parseWatermark(w)
+	if err != nil {
+		return 0, false, err
+	}
+
+	effectiveLow := low - low*tolerance
+	effectiveHigh := high + high*tolerance
+
+	if currentMetricValue >= effectiveLow && currentMetricValue <= effectiveHigh {
+		return 0, true, nil
+	}
+	if currentMetricValue < effectiveLow {
+		return low, false, nil
+	}
+	return high, false, nil
+}
+
+// WatermarkAnnotationLowWatermark and WatermarkAnnotationHighWatermark are the annotation keys the
+// metrics-adapter sets on the external metric value it reports for a trigger using Watermark, so the
+// configured band stays visible for observability even though the metric itself only carries one value.
+const WatermarkAnnotationLowWatermark = "watermark.keda.sh/low-watermark"
+const WatermarkAnnotationHighWatermark = "watermark.keda.sh/high-watermark"
+
+// WatermarkMetricAnnotations returns the annotations the metrics-adapter should attach to the external
+// metric value it reports for a trigger using Watermark. Returns nil if w is nil.
+func WatermarkMetricAnnotations(w *Watermark) map[string]string {
+	if w == nil {
+		return nil
+	}
+	return map[string]string{
+		WatermarkAnnotationLowWatermark:  w.LowWatermark,
+		WatermarkAnnotationHighWatermark: w.HighWatermark,
+	}
+}
+
+// minutesPerWeek is the size of the minute-of-week grid CheckDownscaleForbiddenWindowsAreValid uses to
+// detect overlap between recurring windows (7 days * 24 hours * 60 minutes, Sunday 00:00 = minute 0).
+const minutesPerWeek = 7 * 24 * 60
+
+// parseCronField parses a single cron field into the set of values in [min, max] it matches. Supports
+// "*", single numbers, comma-separated lists, and "a-b" ranges; it does not support step values (e.g.
+// "*/5"), which CheckDownscaleForbiddenWindowsAreValid's callers aren't expected to need for business-
+// hours-style windows.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	if field == "*" {
+		for i := min; i <= max; i++ {
+			values[i] = true
+		}
+		return values, nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		lo, hi := part, part
+		if dash := strings.IndexByte(part, '-'); dash >= 0 {
+			lo, hi = part[:dash], part[dash+1:]
+		}
+		loVal, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		hiVal, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		if loVal > hiVal || loVal < min || hiVal > max {
+			return nil, fmt.Errorf("value %q is out of bounds [%d,%d]", part, min, max)
+		}
+		for i := loVal; i <= hiVal; i++ {
+			values[i] = true
+		}
+	}
+	return values, nil
+}
+
+// parseWeeklyCron parses a standard 5-field cron expression (minute hour dayOfMonth month dayOfWeek)
+// into the set of minutes-of-week it fires at. Only dayOfMonth="*" and month="*" are supported: a
+// schedule that depends on the calendar date can't be reduced to a fixed weekly grid, and silently
+// treating it as one would make the overlap check wrong instead of just limited, so it's rejected
+// outright with a clear error.
+func parseWeeklyCron(expr string) (map[int]bool, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 space-separated fields (minute hour dayOfMonth month dayOfWeek)", expr)
+	}
+	minuteField, hourField, domField, monthField, dowField := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if domField != "*" || monthField != "*" {
+		return nil, fmt.Errorf("cron expression %q: only dayOfMonth=\"*\" and month=\"*\" are supported for overlap validation", expr)
+	}
+
+	minutes, err := parseCronField(minuteField, 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("cron expression %q: invalid minute field: %w", expr, err)
+	}
+	hours, err := parseCronField(hourField, 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("cron expression %q: invalid hour field: %w", expr, err)
+	}
+	daysOfWeek, err := parseCronField(dowField, 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("cron expression %q: invalid day-of-week field: %w", expr, err)
+	}
+
+	matches := make(map[int]bool)
+	for d := range daysOfWeek {
+		for h := range hours {
+			for m := range minutes {
+				matches[d*24*60+h*60+m] = true
+			}
+		}
+	}
+	return matches, nil
+}
+
+// windowMinutesOfWeekUTC returns the set of minutes-of-week (normalized to UTC) during which a
+// TimeWindow is active: the Duration following every minute its Start cron expression matches in its
+// Timezone. Timezone conversion uses the zone's current UTC offset; a window whose active period
+// straddles a DST transition in its own zone may be off by an hour around the transition.
+func windowMinutesOfWeekUTC(window TimeWindow) (map[int]bool, error) {
+	starts, err := parseWeeklyCron(window.Start)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := time.ParseDuration(window.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %w", window.Duration, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration %q must be positive", window.Duration)
+	}
+	lengthMinutes := int(duration.Round(time.Minute) / time.Minute)
+	if lengthMinutes > minutesPerWeek {
+		lengthMinutes = minutesPerWeek
+	}
+
+	tzName := window.Timezone
+	if tzName == "" {
+		tzName = "UTC"
+	}
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", tzName, err)
+	}
+	_, offsetSeconds := time.Now().In(loc).Zone()
+	offsetMinutes := offsetSeconds / 60
+
+	active := make(map[int]bool)
+	for start := range starts {
+		for i := 0; i < lengthMinutes; i++ {
+			minuteUTC := ((start+i-offsetMinutes)%minutesPerWeek + minutesPerWeek) % minutesPerWeek
+			active[minuteUTC] = true
+		}
+	}
+	return active, nil
+}
+
+// CheckDownscaleForbiddenWindowsAreValid checks that every DownscaleForbiddenWindows entry has a valid
+// 5-field cron Start expression and a positive Duration, and that no two windows overlap in wall-clock
+// time, since an overlap would leave the effective minReplicas undefined for that period.
+func CheckDownscaleForbiddenWindowsAreValid(scaledObject *ScaledObject) error {
+	if scaledObject.Spec.Advanced == nil {
+		return nil
+	}
+
+	windows := scaledObject.Spec.Advanced.DownscaleForbiddenWindows
+	active := make([]map[int]bool, len(windows))
+	for i, window := range windows {
+		minutes, err := windowMinutesOfWeekUTC(window)
+		if err != nil {
+			return fmt.Errorf("downscaleForbiddenWindows entry %q: %w", window.Start, err)
+		}
+		active[i] = minutes
+	}
+
+	for i := 0; i < len(windows); i++ {
+		for j := i + 1; j < len(windows); j++ {
+			for m := range active[i] {
+				if active[j][m] {
+					return fmt.Errorf("downscaleForbiddenWindows entries %q and %q overlap", windows[i].Start, windows[j].Start)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckStabilizationWindowSecondsValid checks that every trigger's StabilizationWindowSeconds override,
+// when set, falls within the [0, 3600] range the upstream autoscaling/v2 HPABehavior field it layers
+// onto accepts. The kubebuilder markers on the field enforce this at admission time too; this function
+// is the programmatic equivalent for callers (webhooks, tests) that build a ScaledObject in-process.
+func CheckStabilizationWindowSecondsValid(scaledObject *ScaledObject) error {
+	for _, trigger := range scaledObject.Spec.Triggers {
+		if trigger.StabilizationWindowSeconds == nil {
+			continue
+		}
+		if *trigger.StabilizationWindowSeconds < 0 || *trigger.StabilizationWindowSeconds > 3600 {
+			return fmt.Errorf("trigger %q: stabilizationWindowSeconds must be between 0 and 3600, got %d", trigger.Name, *trigger.StabilizationWindowSeconds)
+		}
+	}
+
+	return nil
+}
+
+// CheckContainerNameValid checks that ContainerName is only set on container-resource triggers, that
+// container-resource triggers always specify one, and, when availableContainerNames is non-nil, that
+// it names one of the scale target's actual containers. The controller passes the container names it
+// fetched from the scale target's Pod spec when building the HPA; CheckReplicaCountBoundsAreValid-style
+// callers that only have the ScaledObject (e.g. the webhook, before the target is resolved) pass nil
+// and get the cheaper structural checks only.
+func CheckContainerNameValid(scaledObject *ScaledObject, availableContainerNames []string) error {
+	var known map[string]bool
+	if availableContainerNames != nil {
+		known = make(map[string]bool, len(availableContainerNames))
+		for _, name := range availableContainerNames {
+			known[name] = true
+		}
+	}
+
+	for _, trigger := range scaledObject.Spec.Triggers {
+		if trigger.Type != containerResourceString {
+			if trigger.ContainerName != "" {
+				return fmt.Errorf("containerName is only supported on %s triggers, but was set on trigger %q of type %s", containerResourceString, trigger.Name, trigger.Type)
+			}
+			continue
+		}
+
+		if trigger.ContainerName == "" {
+			return fmt.Errorf("trigger %q of type %s must specify containerName", trigger.Name, containerResourceString)
+		}
+		if known != nil && !known[trigger.ContainerName] {
+			return fmt.Errorf("trigger %q targets containerName %q which is not a container on the scale target (available: %v)", trigger.Name, trigger.ContainerName, availableContainerNames)
+		}
+	}
+	return nil
+}
+
+// BuildContainerResourceMetricSpec builds the autoscaling/v2 MetricSpec for a container-resource
+// trigger, the HPA-generation counterpart to scaleTriggerFromMetricSpec's ContainerResource case. The
+// controller calls this instead of the Pod-wide ResourceMetricSourceType path whenever trigger.Type is
+// container-resource, so the HPA scales on a single container's cpu/memory usage (e.g. a sidecar)
+// rather than the average across the whole Pod.
+func BuildContainerResourceMetricSpec(trigger ScaleTriggers) (*autoscalingv2.MetricSpec, error) {
+	if trigger.Type != containerResourceString {
+		return nil, fmt.Errorf("trigger %q is not a %s trigger", trigger.Name, containerResourceString)
+	}
+	if trigger.ContainerName == "" {
+		return nil, fmt.Errorf("trigger %q must specify containerName", trigger.Name)
+	}
+
+	resourceName := trigger.Metadata["name"]
+	if resourceName != cpuString && resourceName != memoryString {
+		return nil, fmt.Errorf("trigger %q metadata.name must be %q or %q, got %q", trigger.Name, cpuString, memoryString, resourceName)
+	}
+
+	metricType := trigger.MetricType
+	if metricType == "" {
+		metricType = autoscalingv2.AverageValueMetricType
+	}
+
+	target := autoscalingv2.MetricTarget{Type: metricType}
+	switch metricType {
+	case autoscalingv2.UtilizationMetricType:
+		utilization, err := strconv.ParseInt(trigger.Metadata["value"], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q metadata.value %q is not a valid utilization percentage", trigger.Name, trigger.Metadata["value"])
+		}
+		averageUtilization := int32(utilization)
+		target.AverageUtilization = &averageUtilization
+	default:
+		quantity, err := resource.ParseQuantity(trigger.Metadata["value"])
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q metadata.value %q is not a valid quantity", trigger.Name, trigger.Metadata["value"])
+		}
+		target.AverageValue = &quantity
+	}
+
+	return &autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ContainerResourceMetricSourceType,
+		ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+			Name:      corev1.ResourceName(resourceName),
+			Container: trigger.ContainerName,
+			Target:    target,
+		},
+	}, nil
+}
+
+// resourceMetricTargetMetadata renders a Resource or ContainerResource metric source's target back
+// into the "name"/"value" metadata keys both the cpu/memory scaler and the container-resource trigger
+// expect. It's the inverse of BuildContainerResourceMetricSpec's metadata handling.
+func resourceMetricTargetMetadata(name string, target autoscalingv2.MetricTarget) map[string]string {
+	metadata := map[string]string{"name": name}
+	switch target.Type {
+	case autoscalingv2.UtilizationMetricType:
+		if target.AverageUtilization != nil {
+			metadata["value"] = strconv.Itoa(int(*target.AverageUtilization))
+		}
+	default:
+		if target.AverageValue != nil {
+			metadata["value"] = target.AverageValue.String()
+		}
+	}
+	return metadata
+}
+
 // CheckFallbackValid checks that the fallback supports scalers with an AverageValue metric target.
 // Consequently, it does not support CPU & memory scalers, or scalers targeting a Value metric type.
+// Container-resource scalers are not excluded here and may use fallback as long as they report
+// an AverageValue metric type.
 func CheckFallbackValid(scaledObject *ScaledObject) error {
 	if scaledObject.Spec.Fallback == nil {
 		return nil
@@ -324,3 +841,67 @@ func CheckFallbackValid(scaledObject *ScaledObject) error {
 	}
 	return nil
 }
+
+// NewScaledObjectSpecFromHPA builds a ScaledObjectSpec equivalent to the given HorizontalPodAutoscaler
+// so operators can adopt an existing HPA instead of hand-writing a ScaledObject. Resource and
+// ContainerResource metric sources are mapped onto KEDA triggers; Behavior and the min/max replica
+// bounds are carried over as-is. Metrics with no KEDA-native equivalent are skipped and surfaced in the
+// returned warnings so they can be finished by hand and recorded in ScaledObjectStatus.ConversionWarnings.
+// This includes Object sources (no matching scaler) and External sources: an HPA ExternalMetricSource
+// only carries a metric selector, not the scaler-specific connection metadata (e.g. a Prometheus
+// serverAddress) a KEDA "external" trigger requires, so it can't be mapped automatically either.
+func NewScaledObjectSpecFromHPA(hpa *autoscalingv2.HorizontalPodAutoscaler) (*ScaledObjectSpec, []string) {
+	maxReplicas := hpa.Spec.MaxReplicas
+	spec := &ScaledObjectSpec{
+		ScaleTargetRef: &ScaleTarget{
+			Name:       hpa.Spec.ScaleTargetRef.Name,
+			APIVersion: hpa.Spec.ScaleTargetRef.APIVersion,
+			Kind:       hpa.Spec.ScaleTargetRef.Kind,
+		},
+		MinReplicaCount: hpa.Spec.MinReplicas,
+		MaxReplicaCount: &maxReplicas,
+		Advanced: &AdvancedConfig{
+			HorizontalPodAutoscalerConfig: &HorizontalPodAutoscalerConfig{
+				Name:             hpa.Name,
+				Behavior:         hpa.Spec.Behavior,
+				AdoptExistingHPA: true,
+			},
+		},
+	}
+
+	var warnings []string
+	for _, metric := range hpa.Spec.Metrics {
+		trigger, warning := scaleTriggerFromMetricSpec(metric)
+		if warning != "" {
+			warnings = append(warnings, warning)
+			continue
+		}
+		spec.Triggers = append(spec.Triggers, trigger)
+	}
+
+	return spec, warnings
+}
+
+// scaleTriggerFromMetricSpec maps a single autoscaling/v2 MetricSpec onto a KEDA ScaleTriggers, or
+// returns a human-readable warning if the metric source has no KEDA-native equivalent.
+func scaleTriggerFromMetricSpec(metric autoscalingv2.MetricSpec) (ScaleTriggers, string) {
+	switch metric.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		return ScaleTriggers{
+			Type:       string(metric.Resource.Name),
+			MetricType: metric.Resource.Target.Type,
+			Metadata:   resourceMetricTargetMetadata(string(metric.Resource.Name), metric.Resource.Target),
+		}, ""
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		return ScaleTriggers{
+			Type:          containerResourceString,
+			ContainerName: metric.ContainerResource.Container,
+			MetricType:    metric.ContainerResource.Target.Type,
+			Metadata:      resourceMetricTargetMetadata(string(metric.ContainerResource.Name), metric.ContainerResource.Target),
+		}, ""
+	case autoscalingv2.ExternalMetricSourceType:
+		return ScaleTriggers{}, fmt.Sprintf("external metric %q has no KEDA-native equivalent (requires scaler-specific connection metadata an HPA ExternalMetricSource doesn't carry) and was skipped", metric.External.Metric.Name)
+	default:
+		return ScaleTriggers{}, fmt.Sprintf("metric source of type %s has no KEDA-native equivalent and was skipped", metric.Type)
+	}
+}
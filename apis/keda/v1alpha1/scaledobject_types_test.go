@@ -0,0 +1,501 @@
+/*
+Copyright 2021 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCheckContainerNameValid(t *testing.T) {
+	tests := []struct {
+		name                    string
+		triggers                []ScaleTriggers
+		availableContainerNames []string
+		wantErr                 bool
+	}{
+		{
+			name:     "non container-resource trigger without containerName is fine",
+			triggers: []ScaleTriggers{{Name: "t1", Type: "kafka"}},
+			wantErr:  false,
+		},
+		{
+			name:     "containerName set on a non container-resource trigger is rejected",
+			triggers: []ScaleTriggers{{Name: "t1", Type: "kafka", ContainerName: "app"}},
+			wantErr:  true,
+		},
+		{
+			name:     "container-resource trigger without containerName is rejected",
+			triggers: []ScaleTriggers{{Name: "t1", Type: containerResourceString}},
+			wantErr:  true,
+		},
+		{
+			name:                    "container-resource trigger with unknown containerName is rejected",
+			triggers:                []ScaleTriggers{{Name: "t1", Type: containerResourceString, ContainerName: "sidecar"}},
+			availableContainerNames: []string{"app"},
+			wantErr:                 true,
+		},
+		{
+			name:                    "container-resource trigger with known containerName passes",
+			triggers:                []ScaleTriggers{{Name: "t1", Type: containerResourceString, ContainerName: "app"}},
+			availableContainerNames: []string{"app", "sidecar"},
+			wantErr:                 false,
+		},
+		{
+			name:     "container-resource trigger is not checked against containers when none supplied",
+			triggers: []ScaleTriggers{{Name: "t1", Type: containerResourceString, ContainerName: "app"}},
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			so := &ScaledObject{Spec: ScaledObjectSpec{Triggers: tt.triggers}}
+			err := CheckContainerNameValid(so, tt.availableContainerNames)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckContainerNameValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetEffectiveTriggerMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		so        *ScaledObject
+		trigger   ScaleTriggers
+		wantQuery string
+		wantSame  bool
+	}{
+		{
+			name: "no PrimaryTriggerQueries leaves metadata untouched",
+			so:   &ScaledObject{},
+			trigger: ScaleTriggers{
+				Name:     "t1",
+				Metadata: map[string]string{"query": "original"},
+			},
+			wantSame: true,
+		},
+		{
+			name: "trigger without a query key is left untouched even with an override defined",
+			so: &ScaledObject{Spec: ScaledObjectSpec{
+				PrimaryTriggerQueries: map[string]string{"t1": "overridden"},
+			}},
+			trigger: ScaleTriggers{
+				Name:     "t1",
+				Metadata: map[string]string{"serverAddress": "cpu-doesnt-use-query"},
+			},
+			wantSame: true,
+		},
+		{
+			name: "matching override replaces query",
+			so: &ScaledObject{Spec: ScaledObjectSpec{
+				PrimaryTriggerQueries: map[string]string{"t1": "overridden"},
+			}},
+			trigger: ScaleTriggers{
+				Name:     "t1",
+				Metadata: map[string]string{"query": "original"},
+			},
+			wantQuery: "overridden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.so.GetEffectiveTriggerMetadata(tt.trigger)
+			if tt.wantSame {
+				if got["query"] != tt.trigger.Metadata["query"] {
+					t.Errorf("expected metadata to be left unchanged, got %v", got)
+				}
+				return
+			}
+			if got["query"] != tt.wantQuery {
+				t.Errorf("got query %q, want %q", got["query"], tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestRecalculateEffectiveTriggerQueries(t *testing.T) {
+	so := &ScaledObject{
+		Spec: ScaledObjectSpec{
+			Triggers: []ScaleTriggers{
+				{Name: "primary", Metadata: map[string]string{"query": "original"}},
+				{Name: "cpu", Type: "cpu", Metadata: map[string]string{"name": "cpu"}},
+			},
+			PrimaryTriggerQueries: map[string]string{"primary": "overridden"},
+		},
+	}
+
+	so.RecalculateEffectiveTriggerQueries()
+
+	if got := so.Status.EffectiveTriggerQueries["primary"]; got != "overridden" {
+		t.Errorf("got %q, want %q", got, "overridden")
+	}
+	if _, ok := so.Status.EffectiveTriggerQueries["cpu"]; ok {
+		t.Errorf("cpu trigger has no query metadata and should not appear in status")
+	}
+}
+
+func TestResolveWatermarkTarget(t *testing.T) {
+	tests := []struct {
+		name           string
+		watermark      Watermark
+		value          float64
+		wantWithinBand bool
+		wantTarget     float64
+		wantErr        bool
+	}{
+		{
+			name:           "within band takes no action",
+			watermark:      Watermark{LowWatermark: "10", HighWatermark: "20"},
+			value:          15,
+			wantWithinBand: true,
+		},
+		{
+			name:       "below band targets low watermark",
+			watermark:  Watermark{LowWatermark: "10", HighWatermark: "20"},
+			value:      5,
+			wantTarget: 10,
+		},
+		{
+			name:       "above band targets high watermark",
+			watermark:  Watermark{LowWatermark: "10", HighWatermark: "20"},
+			value:      25,
+			wantTarget: 20,
+		},
+		{
+			name:           "tolerance widens the band",
+			watermark:      Watermark{LowWatermark: "10", HighWatermark: "20", Tolerance: "0.5"},
+			value:          28,
+			wantWithinBand: true,
+		},
+		{
+			name:      "invalid lowWatermark",
+			watermark: Watermark{LowWatermark: "nope", HighWatermark: "20"},
+			value:     15,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, withinBand, err := ResolveWatermarkTarget(&tt.watermark, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveWatermarkTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if withinBand != tt.wantWithinBand {
+				t.Errorf("withinBand = %v, want %v", withinBand, tt.wantWithinBand)
+			}
+			if !withinBand && target != tt.wantTarget {
+				t.Errorf("target = %v, want %v", target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestWatermarkMetricAnnotations(t *testing.T) {
+	if got := WatermarkMetricAnnotations(nil); got != nil {
+		t.Errorf("expected nil annotations for nil watermark, got %v", got)
+	}
+
+	w := &Watermark{LowWatermark: "10", HighWatermark: "20"}
+	got := WatermarkMetricAnnotations(w)
+	if got[WatermarkAnnotationLowWatermark] != "10" || got[WatermarkAnnotationHighWatermark] != "20" {
+		t.Errorf("unexpected annotations: %v", got)
+	}
+}
+
+func TestCheckDownscaleForbiddenWindowsAreValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []TimeWindow
+		wantErr bool
+	}{
+		{
+			name:    "no windows is fine",
+			windows: nil,
+		},
+		{
+			name:    "single valid window",
+			windows: []TimeWindow{{Start: "0 9 * * 1-5", Duration: "8h"}},
+		},
+		{
+			name:    "garbage cron expression is rejected",
+			windows: []TimeWindow{{Start: "not-a-cron", Duration: "8h"}},
+			wantErr: true,
+		},
+		{
+			name:    "non-wildcard dayOfMonth is rejected",
+			windows: []TimeWindow{{Start: "0 9 15 * 1-5", Duration: "8h"}},
+			wantErr: true,
+		},
+		{
+			name:    "zero duration is rejected",
+			windows: []TimeWindow{{Start: "0 9 * * 1-5", Duration: "0h"}},
+			wantErr: true,
+		},
+		{
+			name: "non-overlapping windows pass",
+			windows: []TimeWindow{
+				{Start: "0 9 * * 1-5", Duration: "4h"},
+				{Start: "0 13 * * 1-5", Duration: "4h"},
+			},
+		},
+		{
+			name: "windows with different start times that genuinely overlap are rejected",
+			windows: []TimeWindow{
+				{Start: "0 9 * * 1-5", Duration: "8h"},
+				{Start: "0 14 * * 1-5", Duration: "8h"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "identical windows overlap",
+			windows: []TimeWindow{
+				{Start: "0 9 * * 1-5", Duration: "8h"},
+				{Start: "0 9 * * 1-5", Duration: "8h"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			so := &ScaledObject{Spec: ScaledObjectSpec{Advanced: &AdvancedConfig{DownscaleForbiddenWindows: tt.windows}}}
+			err := CheckDownscaleForbiddenWindowsAreValid(so)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckDownscaleForbiddenWindowsAreValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckStabilizationWindowSecondsValid(t *testing.T) {
+	seconds := func(v int32) *int32 { return &v }
+
+	tests := []struct {
+		name     string
+		triggers []ScaleTriggers
+		wantErr  bool
+	}{
+		{
+			name:     "no override is fine",
+			triggers: []ScaleTriggers{{Name: "t1"}},
+		},
+		{
+			name:     "zero is the lower bound",
+			triggers: []ScaleTriggers{{Name: "t1", StabilizationWindowSeconds: seconds(0)}},
+		},
+		{
+			name:     "3600 is the upper bound",
+			triggers: []ScaleTriggers{{Name: "t1", StabilizationWindowSeconds: seconds(3600)}},
+		},
+		{
+			name:     "negative is rejected",
+			triggers: []ScaleTriggers{{Name: "t1", StabilizationWindowSeconds: seconds(-1)}},
+			wantErr:  true,
+		},
+		{
+			name:     "above 3600 is rejected",
+			triggers: []ScaleTriggers{{Name: "t1", StabilizationWindowSeconds: seconds(3601)}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			so := &ScaledObject{Spec: ScaledObjectSpec{Triggers: tt.triggers}}
+			err := CheckStabilizationWindowSecondsValid(so)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckStabilizationWindowSecondsValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckWatermarksAreValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		so      *ScaledObject
+		wantErr bool
+	}{
+		{
+			name: "no watermarks is fine",
+			so:   &ScaledObject{},
+		},
+		{
+			name: "valid per-trigger watermark",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Triggers: []ScaleTriggers{
+				{Name: "t1", Watermark: &Watermark{LowWatermark: "10", HighWatermark: "20"}},
+			}}},
+		},
+		{
+			name: "per-trigger watermark with low above high is rejected",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Triggers: []ScaleTriggers{
+				{Name: "t1", Watermark: &Watermark{LowWatermark: "20", HighWatermark: "10"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "valid scalingModifiers watermark",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Advanced: &AdvancedConfig{
+				ScalingModifiers: ScalingModifiers{Watermark: &Watermark{LowWatermark: "10", HighWatermark: "20"}},
+			}}},
+		},
+		{
+			name: "scalingModifiers watermark with low above high is rejected",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Advanced: &AdvancedConfig{
+				ScalingModifiers: ScalingModifiers{Watermark: &Watermark{LowWatermark: "20", HighWatermark: "10"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "scalingModifiers watermark with both low and high unparseable is rejected",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Advanced: &AdvancedConfig{
+				ScalingModifiers: ScalingModifiers{Watermark: &Watermark{LowWatermark: "nope", HighWatermark: "also-nope"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "negative tolerance is rejected",
+			so: &ScaledObject{Spec: ScaledObjectSpec{Triggers: []ScaleTriggers{
+				{Name: "t1", Watermark: &Watermark{LowWatermark: "10", HighWatermark: "20", Tolerance: "-1"}},
+			}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWatermarksAreValid(tt.so)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckWatermarksAreValid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+func TestNewScaledObjectSpecFromHPA(t *testing.T) {
+	quantity := resource.MustParse("100m")
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Name: "my-app", Kind: "Deployment", APIVersion: "apps/v1"},
+			MaxReplicas:    10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &quantity},
+					},
+				},
+				{
+					Type:     autoscalingv2.ExternalMetricSourceType,
+					External: &autoscalingv2.ExternalMetricSource{Metric: autoscalingv2.MetricIdentifier{Name: "queue_depth"}},
+				},
+				{
+					Type:   autoscalingv2.ObjectMetricSourceType,
+					Object: &autoscalingv2.ObjectMetricSource{},
+				},
+			},
+		},
+	}
+
+	spec, warnings := NewScaledObjectSpecFromHPA(hpa)
+
+	if len(spec.Triggers) != 1 {
+		t.Fatalf("expected 1 mappable trigger, got %d: %+v", len(spec.Triggers), spec.Triggers)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings (external + object), got %d: %v", len(warnings), warnings)
+	}
+
+	cpuTrigger := spec.Triggers[0]
+	if cpuTrigger.Type != "cpu" {
+		t.Errorf("got trigger type %q, want %q", cpuTrigger.Type, "cpu")
+	}
+	if got := cpuTrigger.Metadata["name"]; got != "cpu" {
+		t.Errorf("got metadata[name] = %q, want %q", got, "cpu")
+	}
+	if got := cpuTrigger.Metadata["value"]; got != "100m" {
+		t.Errorf("got metadata[value] = %q, want %q", got, "100m")
+	}
+
+	// Mutating the source HPA afterwards must not affect the returned spec.
+	hpa.Spec.MaxReplicas = 99
+	if *spec.MaxReplicaCount != 10 {
+		t.Errorf("MaxReplicaCount aliased the source HPA's field: got %d, want 10", *spec.MaxReplicaCount)
+	}
+}
+
+func TestBuildContainerResourceMetricSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger ScaleTriggers
+		wantErr bool
+	}{
+		{
+			name:    "not a container-resource trigger",
+			trigger: ScaleTriggers{Name: "t1", Type: "cpu"},
+			wantErr: true,
+		},
+		{
+			name:    "missing containerName",
+			trigger: ScaleTriggers{Name: "t1", Type: containerResourceString, Metadata: map[string]string{"name": "cpu", "value": "50"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid resource name",
+			trigger: ScaleTriggers{Name: "t1", Type: containerResourceString, ContainerName: "app", Metadata: map[string]string{"name": "disk", "value": "50"}},
+			wantErr: true,
+		},
+		{
+			name:    "utilization target",
+			trigger: ScaleTriggers{Name: "t1", Type: containerResourceString, ContainerName: "app", MetricType: autoscalingv2.UtilizationMetricType, Metadata: map[string]string{"name": "cpu", "value": "50"}},
+			wantErr: false,
+		},
+		{
+			name:    "average value target",
+			trigger: ScaleTriggers{Name: "t1", Type: containerResourceString, ContainerName: "app", Metadata: map[string]string{"name": "memory", "value": "200Mi"}},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := BuildContainerResourceMetricSpec(tt.trigger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildContainerResourceMetricSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if spec.Type != autoscalingv2.ContainerResourceMetricSourceType {
+				t.Errorf("got metric type %s, want %s", spec.Type, autoscalingv2.ContainerResourceMetricSourceType)
+			}
+			if spec.ContainerResource.Container != tt.trigger.ContainerName {
+				t.Errorf("got container %s, want %s", spec.ContainerResource.Container, tt.trigger.ContainerName)
+			}
+		})
+	}
+}